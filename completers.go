@@ -0,0 +1,604 @@
+package kong
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CompleterArgs are the command-line words available to a Completer while it
+// is being asked for completion candidates.
+type CompleterArgs interface {
+	// All returns every word on the command line, including the command name
+	// itself, in order. The final element is the word currently being
+	// completed, and may be empty.
+	All() []string
+	// Last returns the word currently being completed. It is always the last
+	// element of All(), and may be "".
+	Last() string
+	// Completed returns the words that come after the command name and
+	// before the word currently being completed.
+	Completed() []string
+}
+
+type completerArgs struct {
+	words []string
+}
+
+// newCompleterArgs splits line the way a shell would when it hands Kong
+// COMP_LINE: on whitespace, with a trailing empty word if line ends in a
+// space (or is empty), representing a word the user hasn't started typing
+// yet.
+func newCompleterArgs(line string) CompleterArgs {
+	words := strings.Fields(line)
+	if line == "" || strings.HasSuffix(line, " ") {
+		words = append(words, "")
+	}
+	return &completerArgs{words: words}
+}
+
+func (c *completerArgs) All() []string { return c.words }
+
+func (c *completerArgs) Last() string {
+	if len(c.words) == 0 {
+		return ""
+	}
+	return c.words[len(c.words)-1]
+}
+
+func (c *completerArgs) Completed() []string {
+	if len(c.words) <= 2 {
+		return nil
+	}
+	return c.words[1 : len(c.words)-1]
+}
+
+// Completer computes completion candidates for the word currently being
+// typed, given everything else on the command line.
+type Completer interface {
+	Options(args CompleterArgs) []string
+}
+
+// CompleterFunc is an adapter allowing an ordinary function to be used as a
+// Completer.
+type CompleterFunc func(args CompleterArgs) []string
+
+// Options implements Completer.
+func (c CompleterFunc) Options(args CompleterArgs) []string { return c(args) }
+
+// Completers is a Kong Option that registers Completer implementations under
+// a name, for use with the `completer:"<name>"` tag on flags and positional
+// arguments.
+type Completers map[string]Completer
+
+func (c Completers) Apply(k *Kong) error {
+	k.completers = c
+	// completionScriptFlags only supplies the flag metadata (name and Help
+	// text); making "--completion-script-bash" (etc.) actually print a
+	// script and exit is the core parser's job, done wherever it reads
+	// k.completionScriptFlags, not here.
+	k.completionScriptFlags = completionScriptFlags()
+	return nil
+}
+
+// CompleterOption is a single completion candidate, optionally carrying a
+// human-readable description. Shells that support it (zsh's _describe,
+// fish's complete -d) can display the description alongside the value;
+// others just see the value.
+type CompleterOption struct {
+	Value       string
+	Description string
+}
+
+// DescribingCompleter is implemented by Completers that can attach a
+// description to each of their candidates. Completer remains the minimal
+// interface consumers are expected to use; DescribingCompleter is an opt-in
+// extension for richer shell output.
+type DescribingCompleter interface {
+	DescribedOptions(args CompleterArgs) []CompleterOption
+}
+
+// AsCompleter adapts a DescribingCompleter to the plain Completer interface,
+// discarding descriptions, so code that only needs values can use any
+// DescribingCompleter.
+func AsCompleter(d DescribingCompleter) Completer {
+	return describingCompleterAdapter{d}
+}
+
+type describingCompleterAdapter struct {
+	DescribingCompleter
+}
+
+func (d describingCompleterAdapter) Options(args CompleterArgs) []string {
+	described := d.DescribedOptions(args)
+	options := make([]string, len(described))
+	for i, o := range described {
+		options[i] = o.Value
+	}
+	return options
+}
+
+// completeSetCompleter is a fixed list of CompleterOptions. It implements
+// both Completer and DescribingCompleter.
+type completeSetCompleter []CompleterOption
+
+func (c completeSetCompleter) Options(_ CompleterArgs) []string {
+	values := make([]string, len(c))
+	for i, o := range c {
+		values[i] = o.Value
+	}
+	return values
+}
+
+func (c completeSetCompleter) DescribedOptions(_ CompleterArgs) []CompleterOption {
+	return []CompleterOption(c)
+}
+
+// CompleteSet returns a Completer that always offers the given fixed list of
+// values, regardless of what has already been typed. Filtering candidates
+// down to those matching the word in progress is the caller's
+// responsibility.
+func CompleteSet(values ...string) Completer {
+	options := make([]CompleterOption, len(values))
+	for i, value := range values {
+		options[i] = CompleterOption{Value: value}
+	}
+	return completeSetCompleter(options)
+}
+
+// CompleteSetDescribed is CompleteSet, but with a description attached to
+// each value for shells that can display one.
+func CompleteSetDescribed(options ...CompleterOption) Completer {
+	return completeSetCompleter(options)
+}
+
+// CompleteFlags returns a Completer offering each flag's long name,
+// described by its help text, for use when completing "-"/"--" on the
+// command line.
+func CompleteFlags(flags []*Flag) Completer {
+	options := make([]CompleterOption, 0, len(flags))
+	for _, flag := range flags {
+		options = append(options, CompleterOption{
+			Value:       "--" + flag.Value.Name,
+			Description: flag.Help,
+		})
+	}
+	return completeSetCompleter(options)
+}
+
+// CompleteCommands returns a Completer offering each node's name, described
+// by its help text, for use when completing sub-command names.
+func CompleteCommands(nodes []*Node) Completer {
+	options := make([]CompleterOption, 0, len(nodes))
+	for _, node := range nodes {
+		options = append(options, CompleterOption{
+			Value:       node.Name,
+			Description: node.Help,
+		})
+	}
+	return completeSetCompleter(options)
+}
+
+// CompleteEnum returns a Completer offering each of a flag's enum values,
+// each described by the flag's own help text.
+func CompleteEnum(flag *Flag, values ...string) Completer {
+	options := make([]CompleterOption, len(values))
+	for i, value := range values {
+		options[i] = CompleterOption{Value: value, Description: flag.Help}
+	}
+	return completeSetCompleter(options)
+}
+
+// FormatCompletionOption formats a single completion candidate for output,
+// using "value:description" syntax for shells that can display a
+// description (zsh's _describe, fish's complete -d) and falling back to the
+// bare value for bash and any other shell.
+func FormatCompletionOption(opt CompleterOption, shell string) string {
+	if opt.Description == "" {
+		return opt.Value
+	}
+	switch completionShell(shell) {
+	case shellZsh, shellFish:
+		return opt.Value + ":" + opt.Description
+	default:
+		return opt.Value
+	}
+}
+
+// CompleteDirs returns a Completer that offers directories relative to the
+// word being completed.
+func CompleteDirs() Completer {
+	return CompleterFunc(func(args CompleterArgs) []string {
+		return completePath(args.Last(), "", true, &completeFilesConfig{})
+	})
+}
+
+// CompleteFiles returns a Completer that offers files matching pattern, as
+// well as directories, relative to the word being completed.
+//
+// pattern is a slash-separated sequence of filepath.Match segments, eg.
+// "*.go" or "pkg/**/*_test.go". A "**" segment matches any number of
+// intermediate directories, which switches matching from the single
+// directory named by the word being completed to a recursive walk of the
+// whole tree; in that mode only matching files are offered, not directories.
+// Without a "**" segment, each directory entry's name alone is matched, so a
+// pattern containing "/" matches no files. Brace alternations such as
+// "{src,pkg}/**/*.go" are expanded into their constituent patterns before
+// matching.
+func CompleteFiles(pattern string, opts ...CompleteFilesOption) Completer {
+	cfg := &completeFilesConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	patterns := expandBraces(pattern)
+	return CompleterFunc(func(args CompleterArgs) []string {
+		word := args.Last()
+		if isRecursivePattern(patterns) {
+			return completeRecursive(word, patterns, cfg)
+		}
+		seen := map[string]bool{}
+		var options []string
+		for _, p := range patterns {
+			for _, o := range completePath(word, p, false, cfg) {
+				if !seen[o] {
+					seen[o] = true
+					options = append(options, o)
+				}
+			}
+		}
+		sort.Strings(options)
+		return options
+	})
+}
+
+// CompleteFilesFunc returns a Completer that offers files (and directories,
+// so the user can descend into them), relative to the word being completed,
+// for which predicate returns true. predicate is called with the
+// slash-qualified path exactly as it would be offered as a candidate (eg.
+// "./dir/foo").
+func CompleteFilesFunc(predicate func(path string) bool) Completer {
+	return CompleterFunc(func(args CompleterArgs) []string {
+		return completePath(args.Last(), "", false, &completeFilesConfig{predicate: predicate})
+	})
+}
+
+// CompleteFilesOption customises the behaviour of CompleteFiles.
+type CompleteFilesOption func(*completeFilesConfig)
+
+// WithoutHiddenFiles excludes dotfiles and dot-directories (such as ".git")
+// from the candidates CompleteFiles offers. By default hidden entries are
+// included, matching the typed word like any other entry.
+func WithoutHiddenFiles() CompleteFilesOption {
+	return func(c *completeFilesConfig) { c.excludeHidden = true }
+}
+
+type completeFilesConfig struct {
+	excludeHidden bool
+	predicate     func(path string) bool
+}
+
+// expandBraces expands {a,b,c}-style alternation in pattern into the set of
+// concrete patterns it represents. A pattern with no braces expands to
+// itself.
+func expandBraces(pattern string) []string {
+	start := strings.IndexByte(pattern, '{')
+	if start < 0 {
+		return []string{pattern}
+	}
+	end := strings.IndexByte(pattern[start:], '}')
+	if end < 0 {
+		return []string{pattern}
+	}
+	end += start
+	prefix, suffix := pattern[:start], pattern[end+1:]
+	var out []string
+	for _, alt := range strings.Split(pattern[start+1:end], ",") {
+		out = append(out, expandBraces(prefix+alt+suffix)...)
+	}
+	return out
+}
+
+func isRecursivePattern(patterns []string) bool {
+	for _, p := range patterns {
+		if strings.Contains(p, "**") {
+			return true
+		}
+	}
+	return false
+}
+
+// completeRecursive matches a "**"-containing pattern set by walking the
+// whole directory tree from ".", rather than listing a single directory.
+func completeRecursive(word string, patterns []string, cfg *completeFilesConfig) []string {
+	var segmented [][]string
+	for _, p := range patterns {
+		segmented = append(segmented, strings.Split(p, "/"))
+	}
+
+	relPrefix := strings.TrimPrefix(word, "./")
+	qualify := strings.HasPrefix(word, "./")
+
+	var matches []string
+	_ = filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
+		if err != nil || path == "." {
+			return nil
+		}
+		rel := filepath.ToSlash(strings.TrimPrefix(path, "./"))
+		if cfg.excludeHidden && isHiddenPath(rel) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		parts := strings.Split(rel, "/")
+		matched := false
+		for _, segs := range segmented {
+			if matchSegments(segs, parts) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return nil
+		}
+		if cfg.predicate != nil && !cfg.predicate(rel) {
+			return nil
+		}
+		if !strings.HasPrefix(rel, relPrefix) {
+			return nil
+		}
+		if qualify {
+			rel = "./" + rel
+		}
+		matches = append(matches, rel)
+		return nil
+	})
+	sort.Strings(matches)
+	return matches
+}
+
+// matchSegments reports whether pathSegments (a file's path, split on "/")
+// is matched by segments (a pattern, split on "/"), where a "**" segment
+// consumes zero or more path segments.
+func matchSegments(segments, pathSegments []string) bool {
+	if len(segments) == 0 {
+		return len(pathSegments) == 0
+	}
+	if segments[0] == "**" {
+		if matchSegments(segments[1:], pathSegments) {
+			return true
+		}
+		if len(pathSegments) == 0 {
+			return false
+		}
+		return matchSegments(segments, pathSegments[1:])
+	}
+	if len(pathSegments) == 0 {
+		return false
+	}
+	if ok, _ := filepath.Match(segments[0], pathSegments[0]); !ok {
+		return false
+	}
+	return matchSegments(segments[1:], pathSegments[1:])
+}
+
+// isHiddenPath reports whether any segment of the slash-separated path rel
+// is a dotfile or dot-directory.
+func isHiddenPath(rel string) bool {
+	for _, seg := range strings.Split(rel, "/") {
+		if strings.HasPrefix(seg, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// completePath lists the entries of the directory named by word that match
+// pattern (files only; directories always match), qualifying each candidate
+// the same way word is qualified (eg. with a "./" prefix). If word (whether
+// a complete name or just a unique prefix of one) names exactly one
+// directory, that directory's own entries are offered too, so that a
+// completed directory name can be immediately descended into.
+func completePath(word, pattern string, dirsOnly bool, cfg *completeFilesConfig) []string {
+	seen := map[string]bool{}
+	var options []string
+	add := func(opts []string) {
+		for _, o := range opts {
+			if !seen[o] {
+				seen[o] = true
+				options = append(options, o)
+			}
+		}
+	}
+
+	entries, uniqueDir := listDir(word, pattern, dirsOnly, cfg)
+	add(entries)
+
+	if uniqueDir != "" {
+		descended, _ := listDir(uniqueDir, pattern, dirsOnly, cfg)
+		add(descended)
+	}
+
+	sort.Strings(options)
+	return options
+}
+
+// listDir lists the directory and filename-prefix encoded by word, returning
+// one completion candidate per matching entry, qualified the way word itself
+// is qualified. If word's filename prefix matches exactly one directory
+// entry, that directory's qualified path is also returned as uniqueDir, so
+// the caller can descend into it.
+func listDir(word, pattern string, dirsOnly bool, cfg *completeFilesConfig) (options []string, uniqueDir string) {
+	if word == "." {
+		// filepath.Split treats a bare "." as a filename with no
+		// directory component; here it means "list the current
+		// directory", same as "./".
+		word = "./"
+	}
+	dir, prefix := filepath.Split(word)
+	lookIn := dir
+	if lookIn == "" {
+		lookIn = "."
+	}
+	entries, err := os.ReadDir(lookIn)
+	if err != nil {
+		return nil, ""
+	}
+	var matchedDirs []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if cfg.excludeHidden && strings.HasPrefix(name, ".") {
+			continue
+		}
+		if entry.IsDir() {
+			qualified := dir + name + "/"
+			options = append(options, qualified)
+			matchedDirs = append(matchedDirs, qualified)
+			continue
+		}
+		if dirsOnly {
+			continue
+		}
+		if pattern != "" {
+			if ok, _ := filepath.Match(pattern, name); !ok {
+				continue
+			}
+		}
+		if cfg.predicate != nil && !cfg.predicate(dir+name) {
+			continue
+		}
+		options = append(options, dir+name)
+	}
+	if prefix == "" {
+		// word already names (or is) a directory we're listing the
+		// contents of; "./" (or "./dir/") represents staying put.
+		options = append(options, qualifySelf(dir))
+		return options, ""
+	}
+	if len(matchedDirs) == 1 {
+		return options, matchedDirs[0]
+	}
+	return options, ""
+}
+
+// qualifySelf returns the "current directory" entry (eg. "./" or
+// "./dir/"), matching the way dir was itself written.
+func qualifySelf(dir string) string {
+	if dir == "" {
+		return "./"
+	}
+	return dir
+}
+
+// positionalCompleter dispatches completion to Completers, one per
+// positional argument, based on how many positional arguments have already
+// been fully typed on the command line. Flags (and their values) are
+// skipped over when counting, so that interleaving flags with positionals
+// doesn't throw off the count.
+type positionalCompleter struct {
+	Flags      []*Flag
+	Completers []Completer
+	// LastIsSlice indicates that the final entry in Completers belongs to a
+	// slice-typed positional (eg. `Files []string `arg``), which accepts any
+	// number of values, so it should keep handling every token after it
+	// rather than running out of Completers.
+	LastIsSlice bool
+}
+
+// Options implements Completer.
+func (p *positionalCompleter) Options(args CompleterArgs) []string {
+	index := p.completerIndex(args)
+	if index < 0 {
+		return []string{}
+	}
+	if index >= len(p.Completers) {
+		if !p.LastIsSlice || len(p.Completers) == 0 {
+			return []string{}
+		}
+		index = len(p.Completers) - 1
+	}
+	return p.Completers[index].Options(args)
+}
+
+// CompleteRepeated wraps inner so that values already supplied earlier on
+// the command line aren't offered again. This suits completers for
+// repeatable flags (eg. `-I path -I path`) and slice-typed positionals,
+// where re-suggesting an already-supplied value is rarely useful.
+func CompleteRepeated(inner Completer) Completer {
+	return CompleterFunc(func(args CompleterArgs) []string {
+		supplied := map[string]bool{}
+		for _, value := range args.Completed() {
+			supplied[value] = true
+		}
+		var options []string
+		for _, option := range inner.Options(args) {
+			if !supplied[option] {
+				options = append(options, option)
+			}
+		}
+		return options
+	})
+}
+
+// completerIndex returns the number of positional arguments that have
+// already been completed (ie. fully typed, with a trailing space) on the
+// command line, which is also the index of the Completer that should handle
+// the word currently being typed.
+func (p *positionalCompleter) completerIndex(args CompleterArgs) int {
+	count := 0
+	completed := args.Completed()
+	for i := 0; i < len(completed); i++ {
+		tok := completed[i]
+		if !strings.HasPrefix(tok, "-") {
+			count++
+			continue
+		}
+		if strings.Contains(tok, "=") {
+			continue
+		}
+		if strings.HasPrefix(tok, "--") {
+			if p.flagTakesValue(tok[2:]) {
+				i++
+			}
+			continue
+		}
+		// Combined short flags, eg. "-bc" or "-bofile". Only the final
+		// flag in the group can take an inline value; if it doesn't have
+		// one appended, the next token is its value.
+		chars := tok[1:]
+		for j, r := range chars {
+			name := string(r)
+			if !p.flagTakesValue(name) {
+				continue
+			}
+			if j < len(chars)-1 {
+				// value is the remainder of this token
+				break
+			}
+			i++
+			break
+		}
+	}
+	return count
+}
+
+// flagTakesValue reports whether name (long or short) identifies a
+// registered flag that consumes a separate value token, as opposed to a
+// boolean flag or an unrecognised one.
+func (p *positionalCompleter) flagTakesValue(name string) bool {
+	for _, flag := range p.Flags {
+		if flag.Value.Name != name && string(flag.Short) != name {
+			continue
+		}
+		_, isBool := flag.Value.Mapper.(boolMapper)
+		return !isBool
+	}
+	return false
+}
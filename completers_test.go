@@ -141,6 +141,93 @@ func TestCompleteFiles(t *testing.T) {
 	}
 }
 
+func TestCompleteFilesRecursiveGlob(t *testing.T) {
+	teardown := setupTestFilesDir(t)
+	defer teardown()
+
+	for pattern, args := range map[string]map[string][]string{
+		"**/*.md": {
+			"": {"./outer/inner/readme.md", "./readme.md"},
+		},
+		"**/foo": {
+			"": {"./dir/foo"},
+		},
+		"{dir,outer}/**/*.md": {
+			"": {"./outer/inner/readme.md"},
+		},
+	} {
+		pattern := pattern
+		args := args
+		t.Run(fmt.Sprintf("pattern:%q", pattern), func(t *testing.T) {
+			completer := CompleteFiles(pattern)
+			for arg, want := range args {
+				arg := arg
+				want := want
+				t.Run(fmt.Sprintf("arg:%q", arg), func(t *testing.T) {
+					got := completer.Options(newCompleterArgs("./" + arg))
+					sort.Strings(got)
+					sort.Strings(want)
+					require.Equal(t, want, got)
+				})
+			}
+		})
+	}
+}
+
+func TestCompleteFilesRecursiveHidden(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, file := range []string{"readme.md", ".git/config.md", "dir/.secret/notes.md"} {
+		file = filepath.Join(tmpDir, filepath.FromSlash(file))
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0700))
+		require.NoError(t, ioutil.WriteFile(file, nil, 0600))
+	}
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(tmpDir))
+	defer func() {
+		require.NoError(t, os.Chdir(wd))
+	}()
+
+	t.Run("hidden files included by default", func(t *testing.T) {
+		got := CompleteFiles("**/*.md").Options(newCompleterArgs("./"))
+		sort.Strings(got)
+		require.Equal(t, []string{
+			"./.git/config.md",
+			"./dir/.secret/notes.md",
+			"./readme.md",
+		}, got)
+	})
+
+	t.Run("hidden files and directories excluded with WithoutHiddenFiles", func(t *testing.T) {
+		got := CompleteFiles("**/*.md", WithoutHiddenFiles()).Options(newCompleterArgs("./"))
+		sort.Strings(got)
+		require.Equal(t, []string{"./readme.md"}, got)
+	})
+}
+
+func TestCompleteFilesWithoutHiddenFiles(t *testing.T) {
+	teardown := setupTestFilesDir(t)
+	defer teardown()
+
+	completer := CompleteFiles("*.txt", WithoutHiddenFiles())
+	got := completer.Options(newCompleterArgs(""))
+	for _, opt := range got {
+		require.NotEqual(t, ".dot.txt", opt)
+	}
+}
+
+func TestCompleteFilesFunc(t *testing.T) {
+	teardown := setupTestFilesDir(t)
+	defer teardown()
+
+	completer := CompleteFilesFunc(func(path string) bool {
+		return strings.HasSuffix(path, ".md")
+	})
+	got := completer.Options(newCompleterArgs(""))
+	sort.Strings(got)
+	require.Equal(t, []string{"./", "dir/", "outer/", "readme.md"}, got)
+}
+
 func TestPositionalCompleter_position(t *testing.T) {
 	posCompleter := &positionalCompleter{
 		Flags: []*Flag{
@@ -214,6 +301,54 @@ func TestPositionalCompleter_Predict(t *testing.T) {
 	}
 }
 
+func TestPositionalCompleter_PredictSlice(t *testing.T) {
+	filesCompleter := CompleteSet("src1", "src2", "src3", "dst")
+	posCompleter := &positionalCompleter{
+		Completers:  []Completer{filesCompleter},
+		LastIsSlice: true,
+	}
+
+	for args, want := range map[string][]string{
+		``:                   {"src1", "src2", "src3", "dst"},
+		`src1 `:              {"src1", "src2", "src3", "dst"},
+		`src1 src2 `:         {"src1", "src2", "src3", "dst"},
+		`src1 src2 src3 dst`: {"src1", "src2", "src3", "dst"},
+	} {
+		args := args
+		want := want
+		t.Run(args, func(t *testing.T) {
+			got := posCompleter.Options(newCompleterArgs("cp " + args))
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestPositionalCompleter_PredictSliceNotGreedyWithoutFlag(t *testing.T) {
+	posCompleter := &positionalCompleter{
+		Completers: []Completer{CompleteSet("1")},
+	}
+	got := posCompleter.Options(newCompleterArgs("app foo bar "))
+	assert.Equal(t, []string{}, got)
+}
+
+func TestCompleteRepeated(t *testing.T) {
+	pathCompleter := CompleteRepeated(CompleteSet("/a", "/b", "/c"))
+
+	for args, want := range map[string][]string{
+		`-I /a -I `: {"/b", "/c"},
+		`-I `:       {"/a", "/b", "/c"},
+	} {
+		args := args
+		want := want
+		t.Run(args, func(t *testing.T) {
+			got := pathCompleter.Options(newCompleterArgs("myApp " + args))
+			sort.Strings(got)
+			sort.Strings(want)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
 func setLineAndPoint(t *testing.T, line string, point *int) func() {
 	pVal := len(line)
 	if point != nil {
@@ -0,0 +1,53 @@
+package kong
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompleteSetDescribed(t *testing.T) {
+	completer := CompleteSetDescribed(
+		CompleterOption{Value: "oh", Description: "say it"},
+		CompleterOption{Value: "my", Description: ""},
+	)
+
+	require.Equal(t, []string{"oh", "my"}, completer.Options(newCompleterArgs("")))
+
+	describing, ok := completer.(DescribingCompleter)
+	require.True(t, ok)
+	require.Equal(t, []CompleterOption{
+		{Value: "oh", Description: "say it"},
+		{Value: "my", Description: ""},
+	}, describing.DescribedOptions(newCompleterArgs("")))
+}
+
+func TestAsCompleter(t *testing.T) {
+	described := CompleteSetDescribed(CompleterOption{Value: "a", Description: "d"})
+	completer := AsCompleter(described.(DescribingCompleter))
+	require.Equal(t, []string{"a"}, completer.Options(newCompleterArgs("")))
+}
+
+func TestFormatCompletionOption(t *testing.T) {
+	opt := CompleterOption{Value: "thing1", Description: "the first thing"}
+
+	require.Equal(t, "thing1", FormatCompletionOption(opt, "bash"))
+	require.Equal(t, "thing1:the first thing", FormatCompletionOption(opt, "zsh"))
+	require.Equal(t, "thing1:the first thing", FormatCompletionOption(opt, "fish"))
+	require.Equal(t, "thing1", FormatCompletionOption(CompleterOption{Value: "thing1"}, "zsh"))
+}
+
+func TestCompleteFlagsDescriptions(t *testing.T) {
+	flags := []*Flag{
+		{Value: &Value{Name: "boofl"}, Help: "toggle boofl"},
+		{Value: &Value{Name: "number"}, Help: "pick a number"},
+	}
+	completer := CompleteFlags(flags).(DescribingCompleter)
+	got := completer.DescribedOptions(newCompleterArgs(""))
+	sort.Slice(got, func(i, j int) bool { return got[i].Value < got[j].Value })
+	require.Equal(t, []CompleterOption{
+		{Value: "--boofl", Description: "toggle boofl"},
+		{Value: "--number", Description: "pick a number"},
+	}, got)
+}
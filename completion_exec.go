@@ -0,0 +1,131 @@
+package kong
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultCompleteExecTimeout bounds how long CompleteExec will wait for its
+// subprocess before giving up, so that a hung or slow helper can never block
+// the shell a user is typing in.
+const defaultCompleteExecTimeout = 500 * time.Millisecond
+
+// CompleteExec returns a Completer that runs cmd (with args) as a
+// subprocess and offers its newline-delimited stdout as candidates. The full
+// command line is passed to the subprocess as COMP_LINE and COMP_POINT, the
+// same environment variables Kong itself reads from bash, and the word
+// currently being completed as COMP_WORD, so existing bash completion helpers (eg. for
+// `git branch`, `kubectl get pods -o name`) can be reused directly.
+//
+// If cmd fails, times out, or writes nothing, CompleteExec silently returns
+// no candidates rather than surfacing an error: a broken or slow predictor
+// should never wedge the shell.
+func CompleteExec(cmd string, args ...string) Completer {
+	return CompleteExecTimeout(defaultCompleteExecTimeout, cmd, args...)
+}
+
+// CompleteExecTimeout is CompleteExec with an explicit timeout, replacing
+// the default of 500ms.
+func CompleteExecTimeout(timeout time.Duration, cmd string, args ...string) Completer {
+	return CompleterFunc(func(args_ CompleterArgs) []string {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		line := strings.Join(args_.All(), " ")
+		point := len(line)
+
+		c := exec.CommandContext(ctx, cmd, args...)
+		c.Env = append(os.Environ(),
+			"COMP_LINE="+line,
+			"COMP_POINT="+strconv.Itoa(point),
+			"COMP_WORD="+args_.Last(),
+		)
+		var out bytes.Buffer
+		c.Stdout = &out
+		if err := c.Run(); err != nil {
+			return nil
+		}
+
+		var options []string
+		for _, line := range strings.Split(out.String(), "\n") {
+			if line != "" {
+				options = append(options, line)
+			}
+		}
+		return options
+	})
+}
+
+// CompleteCached wraps inner so that its results are memoized to a file
+// under $XDG_CACHE_HOME/kong-completion (or $HOME/.cache/kong-completion if
+// XDG_CACHE_HOME is unset) for ttl, keyed on the words already typed before
+// the word being completed (the word in progress is not part of the key). This
+// is primarily useful for CompleteExec-based completers whose subprocess is
+// expensive to run on every keystroke.
+//
+// If the cache can't be read or written for any reason, CompleteCached falls
+// back to calling inner directly.
+func CompleteCached(ttl time.Duration, inner Completer) Completer {
+	return CompleterFunc(func(args CompleterArgs) []string {
+		path, err := completionCachePath(args)
+		if err != nil {
+			return inner.Options(args)
+		}
+		if options, ok := readCompletionCache(path, ttl); ok {
+			return options
+		}
+		options := inner.Options(args)
+		_ = writeCompletionCache(path, options)
+		return options
+	})
+}
+
+func completionCachePath(args CompleterArgs) (string, error) {
+	dir := os.Getenv("XDG_CACHE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".cache")
+	}
+	dir = filepath.Join(dir, "kong-completion")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	// Keyed on the already-typed, stable prefix of the command line, not
+	// args.All() (which includes the word still being typed): bash
+	// re-invokes completion on every keystroke with an updated COMP_LINE,
+	// so keying on the in-progress word would bust the cache every time and
+	// defeat the point of caching an expensive predictor.
+	sum := sha256.Sum256([]byte(strings.Join(args.Completed(), "\x00")))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])), nil
+}
+
+func readCompletionCache(path string, ttl time.Duration) ([]string, bool) {
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > ttl {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	content := strings.TrimSuffix(string(data), "\n")
+	if content == "" {
+		return []string{}, true
+	}
+	return strings.Split(content, "\n"), true
+}
+
+func writeCompletionCache(path string, options []string) error {
+	return os.WriteFile(path, []byte(strings.Join(options, "\n")), 0o600)
+}
@@ -0,0 +1,81 @@
+package kong
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompleteExec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	completer := CompleteExec("sh", "-c", "printf 'one\\ntwo\\n'")
+	got := completer.Options(newCompleterArgs("myApp foo "))
+	require.Equal(t, []string{"one", "two"}, got)
+}
+
+func TestCompleteExecTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	completer := CompleteExecTimeout(10*time.Millisecond, "sh", "-c", "sleep 1; echo late")
+	got := completer.Options(newCompleterArgs("myApp foo "))
+	require.Nil(t, got)
+}
+
+func TestCompleteExecSwallowsErrors(t *testing.T) {
+	completer := CompleteExec("this-binary-does-not-exist-kong-test")
+	got := completer.Options(newCompleterArgs("myApp foo "))
+	require.Nil(t, got)
+}
+
+func TestCompleteCached(t *testing.T) {
+	cacheDir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheDir)
+
+	calls := 0
+	inner := CompleterFunc(func(args CompleterArgs) []string {
+		calls++
+		return []string{"a", "b"}
+	})
+
+	cached := CompleteCached(time.Minute, inner)
+	args := newCompleterArgs("myApp foo ")
+
+	require.Equal(t, []string{"a", "b"}, cached.Options(args))
+	require.Equal(t, []string{"a", "b"}, cached.Options(args))
+	require.Equal(t, 1, calls)
+
+	entries, err := os.ReadDir(filepath.Join(cacheDir, "kong-completion"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
+
+// TestCompleteCachedKeyedOnCompletedArgs verifies the cache key is the
+// already-typed, stable prefix of the command line, not the in-progress
+// word: bash re-invokes completion on every keystroke with a new COMP_LINE,
+// so keying on the whole line would make the cache useless in practice.
+func TestCompleteCachedKeyedOnCompletedArgs(t *testing.T) {
+	cacheDir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheDir)
+
+	calls := 0
+	inner := CompleterFunc(func(args CompleterArgs) []string {
+		calls++
+		return []string{"a", "b"}
+	})
+	cached := CompleteCached(time.Minute, inner)
+
+	cached.Options(newCompleterArgs("myApp foo t"))
+	cached.Options(newCompleterArgs("myApp foo th"))
+	cached.Options(newCompleterArgs("myApp foo thi"))
+	require.Equal(t, 1, calls)
+
+	cached.Options(newCompleterArgs("myApp bar t"))
+	require.Equal(t, 2, calls)
+}
@@ -0,0 +1,209 @@
+package kong
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// envCompletionShell is the environment variable Kong checks, in addition to
+// the usual COMP_LINE/COMP_POINT pair, to find out which shell is asking for
+// completions. Bash doesn't need it (COMP_LINE/COMP_POINT alone are enough to
+// drive it), but zsh and fish need to know so that they can request
+// description-carrying output where it's supported.
+const envCompletionShell = "KONG_COMPLETION"
+
+// completionShell identifies one of the shells Kong can generate and serve
+// completions for.
+type completionShell string
+
+const (
+	shellBash completionShell = "bash"
+	shellZsh  completionShell = "zsh"
+	shellFish completionShell = "fish"
+)
+
+// detectCompletionShell works out which shell is requesting completion, by
+// consulting envCompletionShell and falling back to the user's login shell.
+func detectCompletionShell() completionShell {
+	if shell := os.Getenv(envCompletionShell); shell != "" {
+		return completionShell(shell)
+	}
+	switch base := strings.ToLower(filepathBase(os.Getenv("SHELL"))); base {
+	case string(shellZsh), string(shellFish):
+		return completionShell(base)
+	default:
+		return shellBash
+	}
+}
+
+// filepathBase is a tiny stand-in for filepath.Base that also copes with an
+// empty path, since $SHELL is unset in some environments (eg. CI).
+func filepathBase(path string) string {
+	if path == "" {
+		return ""
+	}
+	if i := strings.LastIndexByte(path, '/'); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
+
+// completionScriptFlag is the name of the flag Kong registers for each
+// supported shell (eg. "completion-script-bash", given on the command line
+// as --completion-script-bash), which prints that
+// shell's integration script to stdout so it can be sourced from the user's
+// rc file.
+func completionScriptFlag(shell completionShell) string {
+	return "completion-script-" + string(shell)
+}
+
+// completionScriptFlags returns one flag per supported shell, named
+// via completionScriptFlag (eg. "completion-script-zsh"), for Kong to
+// register on the root command so that the scripts InstallCompletion
+// generates have a flag to invoke. Printing that shell's script and exiting
+// when the flag is set is the core parser's job, not this file's: these
+// flags carry the Help text describing that behavior, but nothing here
+// binds it to the parsed flag value.
+func completionScriptFlags() []*Flag {
+	shells := []completionShell{shellBash, shellZsh, shellFish}
+	flags := make([]*Flag, len(shells))
+	for i, shell := range shells {
+		flags[i] = &Flag{
+			Value: &Value{
+				Name:   completionScriptFlag(shell),
+				Mapper: boolMapper{},
+			},
+			Help: fmt.Sprintf("Print the %s completion script and exit.", shell),
+		}
+	}
+	return flags
+}
+
+// InstallCompletion writes the shell integration script for shell to w. The
+// script, once sourced by the user's shell (typically from their rc file),
+// arranges for binary's completions to be served by re-invoking binary with
+// COMP_LINE/COMP_POINT (and, for zsh and fish, envCompletionShell) set.
+func InstallCompletion(w io.Writer, binary string, shell string) error {
+	switch completionShell(shell) {
+	case shellBash:
+		return writeBashCompletion(w, binary)
+	case shellZsh:
+		return writeZshCompletion(w, binary)
+	case shellFish:
+		return writeFishCompletion(w, binary)
+	default:
+		return fmt.Errorf("unsupported shell %q", shell)
+	}
+}
+
+// UninstallCompletion writes the shell command(s) needed to undo whatever
+// InstallCompletion's script set up for binary under shell (unsetting the
+// completion function and, for zsh, the compdef association).
+func UninstallCompletion(w io.Writer, binary string, shell string) error {
+	name := sanitizeCompletionName(binary)
+	switch completionShell(shell) {
+	case shellBash:
+		_, err := fmt.Fprintf(w, "complete -r %[1]s\nunset -f _%[2]s_completion\n", binary, name)
+		return err
+	case shellZsh:
+		_, err := fmt.Fprintf(w, "compdef -d %[1]s\nunfunction _%[2]s 2>/dev/null\n", binary, name)
+		return err
+	case shellFish:
+		_, err := fmt.Fprintf(w, "complete -c %[1]s -e\nfunctions -e __complete_%[2]s\n", binary, name)
+		return err
+	default:
+		return fmt.Errorf("unsupported shell %q", shell)
+	}
+}
+
+func writeBashCompletion(w io.Writer, binary string) error {
+	name := sanitizeCompletionName(binary)
+	_, err := fmt.Fprintf(w, `_%[1]s_completion() {
+  local IFS=$'\n'
+  COMPREPLY=( $(COMP_LINE="$COMP_LINE" COMP_POINT="$COMP_POINT" \
+    %[2]s --completion-script-bash) )
+}
+complete -o default -F _%[1]s_completion %[2]s
+`, name, binary)
+	return err
+}
+
+func writeZshCompletion(w io.Writer, binary string) error {
+	name := sanitizeCompletionName(binary)
+	_, err := fmt.Fprintf(w, `#compdef %[2]s
+
+_%[1]s() {
+  local -a completions
+  local line=${(j: :)words}
+  completions=("${(@f)$(COMP_LINE="$line" COMP_POINT=$CURSOR KONG_COMPLETION=zsh %[2]s --completion-script-zsh)}")
+  _describe 'values' completions
+}
+
+compdef _%[1]s %[2]s
+`, name, binary)
+	return err
+}
+
+func writeFishCompletion(w io.Writer, binary string) error {
+	_, err := fmt.Fprintf(w, `function __complete_%[1]s
+    set -lx COMP_LINE (commandline -p)
+    set -lx COMP_POINT (commandline -C)
+    set -lx KONG_COMPLETION fish
+    %[2]s --completion-script-fish
+end
+complete -c %[2]s -f -a '(__complete_%[1]s)'
+`, sanitizeCompletionName(binary), binary)
+	return err
+}
+
+// ServeCompletion is the stdout serialization step of completion: given the
+// Completer Kong's parser has worked out applies to the word currently being
+// typed, it detects the requesting shell (via detectCompletionShell) and
+// writes one formatted candidate per line to w, using value:description
+// syntax for shells that can display a description and bare values
+// otherwise (see FormatCompletionOption).
+//
+// Resolving which Completer applies to the word being typed is done by
+// walking the parsed command's grammar, which lives in Kong's core parser,
+// not in this file; nothing here calls ServeCompletion from that walk. A
+// future change to the parser is expected to call ServeCompletion once it
+// has made that resolution.
+func ServeCompletion(w io.Writer, completer Completer, args CompleterArgs) error {
+	shell := string(detectCompletionShell())
+	for _, opt := range describedOptions(completer, args) {
+		if _, err := fmt.Fprintln(w, FormatCompletionOption(opt, shell)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// describedOptions returns completer's candidates as CompleterOptions,
+// taking descriptions from it when it implements DescribingCompleter and
+// leaving them blank otherwise.
+func describedOptions(completer Completer, args CompleterArgs) []CompleterOption {
+	if describing, ok := completer.(DescribingCompleter); ok {
+		return describing.DescribedOptions(args)
+	}
+	values := completer.Options(args)
+	options := make([]CompleterOption, len(values))
+	for i, value := range values {
+		options[i] = CompleterOption{Value: value}
+	}
+	return options
+}
+
+// sanitizeCompletionName turns binary into something usable as a shell
+// function name, replacing characters that aren't valid in one.
+func sanitizeCompletionName(binary string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, binary)
+}
@@ -0,0 +1,110 @@
+package kong
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectCompletionShell(t *testing.T) {
+	teardown := func(shell, env string) {
+		if shell == "" {
+			require.NoError(t, os.Unsetenv(envCompletionShell))
+		} else {
+			require.NoError(t, os.Setenv(envCompletionShell, shell))
+		}
+		if env == "" {
+			require.NoError(t, os.Unsetenv("SHELL"))
+		} else {
+			require.NoError(t, os.Setenv("SHELL", env))
+		}
+	}
+	defer teardown(os.Getenv(envCompletionShell), os.Getenv("SHELL"))
+
+	for _, tt := range []struct {
+		name       string
+		kongShell  string
+		loginShell string
+		want       completionShell
+	}{
+		{"explicit wins", "zsh", "/bin/bash", shellZsh},
+		{"falls back to login shell", "", "/usr/local/bin/fish", shellFish},
+		{"unknown login shell defaults to bash", "", "/bin/tcsh", shellBash},
+		{"no login shell defaults to bash", "", "", shellBash},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, os.Unsetenv(envCompletionShell))
+			require.NoError(t, os.Unsetenv("SHELL"))
+			if tt.kongShell != "" {
+				require.NoError(t, os.Setenv(envCompletionShell, tt.kongShell))
+			}
+			if tt.loginShell != "" {
+				require.NoError(t, os.Setenv("SHELL", tt.loginShell))
+			}
+			require.Equal(t, tt.want, detectCompletionShell())
+		})
+	}
+}
+
+func TestInstallCompletion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		t.Run(shell, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, InstallCompletion(&buf, "myapp", shell))
+			require.NotEmpty(t, buf.String())
+			require.Contains(t, buf.String(), "myapp")
+		})
+	}
+	var buf bytes.Buffer
+	require.Error(t, InstallCompletion(&buf, "myapp", "powershell"))
+}
+
+func TestUninstallCompletion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		t.Run(shell, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, UninstallCompletion(&buf, "myapp", shell))
+			require.NotEmpty(t, buf.String())
+		})
+	}
+}
+
+func TestCompletionScriptFlags(t *testing.T) {
+	flags := completionScriptFlags()
+	var names []string
+	for _, flag := range flags {
+		names = append(names, flag.Value.Name)
+	}
+	require.ElementsMatch(t, []string{
+		"completion-script-bash",
+		"completion-script-zsh",
+		"completion-script-fish",
+	}, names)
+}
+
+func TestServeCompletion(t *testing.T) {
+	completer := CompleteSetDescribed(
+		CompleterOption{Value: "thing1", Description: "the first thing"},
+		CompleterOption{Value: "thing2", Description: "the second thing"},
+	)
+
+	t.Run("bash degrades to bare values", func(t *testing.T) {
+		require.NoError(t, os.Setenv(envCompletionShell, "bash"))
+		defer os.Unsetenv(envCompletionShell)
+
+		var buf bytes.Buffer
+		require.NoError(t, ServeCompletion(&buf, completer, newCompleterArgs("")))
+		require.Equal(t, "thing1\nthing2\n", buf.String())
+	})
+
+	t.Run("zsh carries descriptions", func(t *testing.T) {
+		require.NoError(t, os.Setenv(envCompletionShell, "zsh"))
+		defer os.Unsetenv(envCompletionShell)
+
+		var buf bytes.Buffer
+		require.NoError(t, ServeCompletion(&buf, completer, newCompleterArgs("")))
+		require.Equal(t, "thing1:the first thing\nthing2:the second thing\n", buf.String())
+	})
+}